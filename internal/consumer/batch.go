@@ -0,0 +1,106 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consumer
+
+import (
+	"time"
+
+	"github.com/uber-go/kafka-client/kafka"
+)
+
+// messageBatch is the concrete kafka.MessageBatch handed out on
+// MessageBatches. Messages within a batch are always from the same
+// topic-partition and are delivered in offset order, so the partition
+// consumer only needs the last, highest-offset message to know where to
+// commit or skip to - but every message still has its own Ack/Nack
+// tracking its inflight state, so Ack/Nack on the batch must walk and
+// acknowledge all of them, not just the last.
+type messageBatch struct {
+	messages []kafka.Message
+}
+
+// Messages returns the messages that make up this batch.
+func (b *messageBatch) Messages() []kafka.Message {
+	return b.messages
+}
+
+// Ack acks every message in the batch, committing up to its highest offset.
+func (b *messageBatch) Ack() {
+	for _, msg := range b.messages {
+		msg.Ack()
+	}
+}
+
+// Nack nacks every message in the batch, skipping up to its highest offset.
+func (b *messageBatch) Nack() {
+	for _, msg := range b.messages {
+		msg.Nack()
+	}
+}
+
+// batchLoop fans messages off msgCh into per topic-partition batches of
+// up to Options.BatchSize messages, flushing early on
+// Options.BatchLingerInterval so a slow partition doesn't stall its
+// batch indefinitely. newConsumer defaults BatchLingerInterval whenever
+// BatchSize is set, but the ticker is still guarded here - time.Ticker
+// panics on a non-positive duration, and a nil tickerC simply never
+// fires, degrading gracefully to a size-only batch.
+func (c *consumerImpl) batchLoop() {
+	pending := make(map[partitionKey][]kafka.Message)
+	var tickerC <-chan time.Time
+	if c.options.BatchLingerInterval > 0 {
+		ticker := time.NewTicker(c.options.BatchLingerInterval)
+		defer ticker.Stop()
+		tickerC = ticker.C
+	}
+
+	flush := func(key partitionKey) {
+		msgs := pending[key]
+		if len(msgs) == 0 {
+			return
+		}
+		delete(pending, key)
+		c.batchCh <- &messageBatch{messages: msgs}
+	}
+
+	for {
+		select {
+		case msg, ok := <-c.msgCh:
+			if !ok {
+				return
+			}
+			key := partitionKey{topic: msg.Topic(), partition: msg.Partition()}
+			pending[key] = append(pending[key], msg)
+			if len(pending[key]) >= c.options.BatchSize {
+				flush(key)
+			}
+		case <-tickerC:
+			for key := range pending {
+				flush(key)
+			}
+		case <-c.stopC:
+			for key := range pending {
+				flush(key)
+			}
+			return
+		}
+	}
+}
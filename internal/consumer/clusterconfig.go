@@ -0,0 +1,46 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consumer
+
+import (
+	cluster "github.com/bsm/sarama-cluster"
+)
+
+// NewClusterConfig builds the cluster.Config that must be used to
+// construct the SaramaConsumer passed to New, so that
+// options.BalanceStrategy and options.GroupMemberUserData are actually
+// applied during the group's partition assignment instead of only
+// affecting this package's own bookkeeping.
+//
+// options.BalanceStrategy maps directly onto bsm/sarama-cluster's own
+// PartitionStrategy, which only has the two values below - there is no
+// third option to fall through to.
+func NewClusterConfig(options *Options) *cluster.Config {
+	cfg := cluster.NewConfig()
+	cfg.Group.Member.UserData = options.GroupMemberUserData
+	switch options.BalanceStrategy {
+	case RoundRobin:
+		cfg.Group.PartitionStrategy = cluster.StrategyRoundRobin
+	default:
+		cfg.Group.PartitionStrategy = cluster.StrategyRange
+	}
+	return cfg
+}
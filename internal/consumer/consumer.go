@@ -21,9 +21,15 @@
 package consumer
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Shopify/sarama"
 	cluster "github.com/bsm/sarama-cluster"
 	"github.com/uber-go/kafka-client/internal/metrics"
 	"github.com/uber-go/kafka-client/internal/util"
@@ -32,25 +38,61 @@ import (
 	"go.uber.org/zap"
 )
 
+// RebalanceProtocol controls how a consumer reacts to a partition
+// rebalance notification from the underlying consumer group.
+type RebalanceProtocol int
+
+const (
+	// Eager is the stop-the-world protocol: every owned partition is
+	// stopped and recreated on every rebalance, regardless of whether
+	// it was actually reassigned.
+	Eager RebalanceProtocol = iota
+	// Cooperative is the incremental protocol: only partitions that
+	// were actually released are stopped, partitions that remain
+	// owned keep running uninterrupted.
+	Cooperative
+)
+
 type (
+	// partitionKey identifies a partition of a specific topic. A plain
+	// int32 partition id is no longer enough to key the partition map
+	// now that a single consumer can be subscribed to many topics.
+	partitionKey struct {
+		topic     string
+		partition int32
+	}
 	partitionMap struct {
-		partitions map[int32]*partitionConsumer
+		mu         sync.RWMutex
+		partitions map[partitionKey]*partitionConsumer
 	}
 	// consumerImpl is an implementation of kafka consumer
 	consumerImpl struct {
-		name       string
-		topic      string
-		dlqTopic   string
-		consumer   SaramaConsumer
-		partitions partitionMap
-		msgCh      chan kafka.Message
-		dlq        DLQ
-		tally      tally.Scope
-		logger     *zap.Logger
-		options    *Options
-		lifecycle  *util.RunLifecycle
-		stopC      chan struct{}
-		doneC      chan struct{}
+		name         string
+		topicsMu     sync.RWMutex
+		topics       map[string]struct{}
+		topicPattern *regexp.Regexp
+		dlqTopic     string
+		consumer     SaramaConsumer
+		partitions   partitionMap
+		fetchCh      chan kafka.Message
+		msgCh        chan kafka.Message
+		batchCh      chan kafka.MessageBatch
+		dlq          DLQ
+		tally        tally.Scope
+		logger       *zap.Logger
+		options      *Options
+		lifecycle    *util.RunLifecycle
+		lagMu        sync.RWMutex
+		lag          map[string]map[int32]int64
+		started      int32
+		healthMu     sync.RWMutex
+		claimed      map[string]struct{}
+		lastEventAt  time.Time
+		lastFatalAt  time.Time
+		shutdownCtx  context.Context
+		inflight     int64
+		stopC        chan struct{}
+		doneC        chan struct{}
 	}
 )
 
@@ -64,6 +106,14 @@ type (
 // During failures / partition rebalances, this consumer does a
 // best effort at avoiding duplicates, but the application must be
 // designed for idempotency
+//
+// consumer must already be built from a cluster.Config produced by
+// NewClusterConfig(options) - that is where options.BalanceStrategy and
+// options.GroupMemberUserData actually take effect during the group's
+// JoinGroup/SyncGroup exchange. Passing a consumer built from any other
+// config makes those two options no-ops. options.BalanceStrategy only
+// ever selects between bsm/sarama-cluster's two built-in strategies;
+// there is no pluggable assignment algorithm to wire in beyond that.
 func New(
 	config *kafka.ConsumerConfig,
 	consumer SaramaConsumer,
@@ -81,20 +131,49 @@ func newConsumer(config *kafka.ConsumerConfig,
 	dlq DLQ,
 	scope tally.Scope,
 	log *zap.Logger) (*consumerImpl, error) {
+	topics := make(map[string]struct{}, len(config.Topics))
+	for _, t := range config.Topics {
+		topics[t] = struct{}{}
+	}
+	var pattern *regexp.Regexp
+	if config.TopicPattern != "" {
+		re, err := regexp.Compile(config.TopicPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid topic pattern %q: %v", config.TopicPattern, err)
+		}
+		pattern = re
+	}
+	if options.BalanceStrategy == "" {
+		options.BalanceStrategy = Range
+	}
+	var batchCh chan kafka.MessageBatch
+	if options.BatchSize > 0 {
+		batchCh = make(chan kafka.MessageBatch, options.RcvBufferSize)
+		if options.BatchLingerInterval <= 0 {
+			// BatchSize alone would leave a partition's last,
+			// under-sized batch pending forever; fall back to the
+			// same cadence as offset commits so it still flushes.
+			options.BatchLingerInterval = options.OffsetCommitInterval
+		}
+	}
 	return &consumerImpl{
-		name:       config.GroupName,
-		topic:      config.Topic,
-		dlqTopic:   config.DLQ.Name,
-		consumer:   consumer,
-		dlq:        dlq,
-		msgCh:      make(chan kafka.Message, options.RcvBufferSize),
-		partitions: newPartitionMap(),
-		tally:      scope.Tagged(map[string]string{"topic": config.Topic}),
-		logger:     log,
-		options:    options,
-		stopC:      make(chan struct{}),
-		doneC:      make(chan struct{}),
-		lifecycle:  util.NewRunLifecycle(config.Topic+"-consumer", log),
+		name:         config.GroupName,
+		topics:       topics,
+		topicPattern: pattern,
+		dlqTopic:     config.DLQ.Name,
+		consumer:     consumer,
+		dlq:          dlq,
+		fetchCh:      make(chan kafka.Message, options.RcvBufferSize),
+		msgCh:        make(chan kafka.Message, options.RcvBufferSize),
+		batchCh:      batchCh,
+		partitions:   newPartitionMap(),
+		claimed:      make(map[string]struct{}, len(topics)),
+		tally:        scope.Tagged(map[string]string{"group": config.GroupName}),
+		logger:       log,
+		options:      options,
+		stopC:        make(chan struct{}),
+		doneC:        make(chan struct{}),
+		lifecycle:    util.NewRunLifecycle(config.GroupName+"-consumer", log),
 	}, nil
 }
 
@@ -103,29 +182,76 @@ func (c *consumerImpl) Name() string {
 	return c.name
 }
 
-// Topics returns the topics that this consumer is subscribed to
+// Topics returns the topics that this consumer is currently subscribed to.
+// When a topic pattern is configured, this list grows as new matching
+// topics are discovered on the cluster.
 func (c *consumerImpl) Topics() []string {
-	return []string{c.topic}
+	c.topicsMu.RLock()
+	defer c.topicsMu.RUnlock()
+	topics := make([]string, 0, len(c.topics))
+	for t := range c.topics {
+		topics = append(topics, t)
+	}
+	return topics
 }
 
 // Start starts the consumer
 func (c *consumerImpl) Start() error {
 	return c.lifecycle.Start(func() error {
 		go c.eventLoop()
+		if c.topicPattern != nil && c.options.MetadataRefreshInterval > 0 {
+			go c.topicDiscoveryLoop()
+		}
+		if c.options.LagReportInterval > 0 {
+			go c.lagReportLoop()
+		}
+		if c.batchCh != nil {
+			go c.batchLoop()
+		}
+		go c.trackingLoop()
+		atomic.StoreInt32(&c.started, 1)
 		c.tally.Counter(metrics.KafkaConsumerStarted).Inc(1)
 		return nil
 	})
 }
 
-// Stop stops the consumer
+// Stop stops the consumer. It signals shutdown and returns immediately;
+// draining happens in the background, up to the default deadline of
+// 2*Options.OffsetCommitInterval per partition. Callers that need to
+// block until shutdown completes, bound overall shutdown time, or
+// observe how many messages are left uncommitted should use
+// StopWithContext instead.
 func (c *consumerImpl) Stop() {
 	c.lifecycle.Stop(func() {
-		c.logger.Info("consumer shutting down", zap.String("topic", c.topic))
+		c.logger.Info("consumer shutting down", zap.String("name", c.name))
+		c.shutdownCtx = context.Background()
 		close(c.stopC)
 		c.tally.Counter(metrics.KafkaConsumerStopped).Inc(1)
 	})
 }
 
+// StopWithContext stops the consumer, blocking until shutdown completes
+// or ctx is done, whichever comes first, and bounding the drain by ctx.
+// It stops fetching new messages immediately, then lets in-flight
+// application handlers finish Acking/Nacking until either every message
+// has been accounted for or ctx is done, and finally force-commits
+// offsets and closes. It returns ctx.Err() if the context is done before
+// the drain completes.
+func (c *consumerImpl) StopWithContext(ctx context.Context) error {
+	c.lifecycle.Stop(func() {
+		c.logger.Info("consumer shutting down", zap.String("name", c.name))
+		c.shutdownCtx = ctx
+		close(c.stopC)
+		c.tally.Counter(metrics.KafkaConsumerStopped).Inc(1)
+	})
+	select {
+	case <-c.doneC:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Closed returns a channel which will closed after this consumer is shutown
 func (c *consumerImpl) Closed() <-chan struct{} {
 	return c.doneC
@@ -136,70 +262,372 @@ func (c *consumerImpl) Messages() <-chan kafka.Message {
 	return c.msgCh
 }
 
+// MessageBatches returns a channel of message batches, an alternative to
+// Messages for applications that write in bulk. Each batch holds up to
+// Options.BatchSize messages from the same topic-partition and exposes a
+// single Ack/Nack that commits or skips the batch's highest offset. The
+// channel is nil unless Options.BatchSize is set, in which case the two
+// delivery modes are mutually exclusive - messages are routed to exactly
+// one of Messages or MessageBatches.
+func (c *consumerImpl) MessageBatches() <-chan kafka.MessageBatch {
+	return c.batchCh
+}
+
+// Lag returns the most recently computed consumer lag, per topic and
+// partition, as HighWaterMark - CommittedOffset. It reflects the last
+// run of the lag reporting loop and is empty until Options.LagReportInterval
+// has elapsed at least once after Start.
+func (c *consumerImpl) Lag() map[string]map[int32]int64 {
+	c.lagMu.RLock()
+	defer c.lagMu.RUnlock()
+	result := make(map[string]map[int32]int64, len(c.lag))
+	for topic, partitions := range c.lag {
+		cp := make(map[int32]int64, len(partitions))
+		for p, l := range partitions {
+			cp[p] = l
+		}
+		result[topic] = cp
+	}
+	return result
+}
+
+// Ready reports whether the consumer has completed its initial
+// rebalance: Start has returned, every subscribed topic has had at
+// least one partition claimed, and for each currently owned partition
+// the committed offset has either advanced past its initial position or
+// reached the high-water mark. It is intended for an HTTP /readyz probe.
+func (c *consumerImpl) Ready() bool {
+	if atomic.LoadInt32(&c.started) == 0 {
+		return false
+	}
+	c.topicsMu.RLock()
+	topics := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		topics = append(topics, topic)
+	}
+	c.topicsMu.RUnlock()
+
+	c.healthMu.RLock()
+	for _, topic := range topics {
+		if _, ok := c.claimed[topic]; !ok {
+			c.healthMu.RUnlock()
+			return false
+		}
+	}
+	c.healthMu.RUnlock()
+
+	hwm := c.consumer.HighWaterMarks()
+	for key, p := range c.partitions.Snapshot() {
+		topicHWM, ok := hwm[key.topic]
+		if !ok {
+			continue
+		}
+		high, ok := topicHWM[key.partition]
+		if !ok {
+			continue
+		}
+		committed := p.CommittedOffset()
+		if committed <= p.InitialOffset() && committed < high {
+			return false
+		}
+	}
+	return true
+}
+
+// Healthy reports whether the consumer still looks alive: the event
+// loop has observed a partition or notification event within
+// Options.LivenessTimeout, and no fatal, non-retryable sarama error has
+// been seen in that same window. It is intended for an HTTP /livez probe.
+func (c *consumerImpl) Healthy() bool {
+	c.healthMu.RLock()
+	defer c.healthMu.RUnlock()
+	if c.options.LivenessTimeout <= 0 {
+		return true
+	}
+	if c.lastEventAt.IsZero() || time.Since(c.lastEventAt) > c.options.LivenessTimeout {
+		return false
+	}
+	if !c.lastFatalAt.IsZero() && time.Since(c.lastFatalAt) < c.options.LivenessTimeout {
+		return false
+	}
+	return true
+}
+
+// isFatalError reports whether err is a non-retryable sarama error that
+// a rebalance or retry cannot recover from on its own, e.g. the consumer
+// group or topic has been misconfigured or the principal is no longer
+// authorized.
+func isFatalError(err error) bool {
+	kerr := err
+	if cerr, ok := err.(*sarama.ConsumerError); ok {
+		kerr = cerr.Err
+	}
+	switch kerr {
+	case sarama.ErrUnknownTopicOrPartition,
+		sarama.ErrTopicAuthorizationFailed,
+		sarama.ErrGroupAuthorizationFailed,
+		sarama.ErrClusterAuthorizationFailed,
+		sarama.ErrInvalidTopic:
+		return true
+	}
+	return false
+}
+
 // eventLoop is the main event loop for this consumer
 func (c *consumerImpl) eventLoop() {
-	c.logger.Info("consumer started", zap.String("topic", c.topic))
+	c.logger.Info("consumer started", zap.String("name", c.name))
 	for {
 		select {
 		case pc := <-c.consumer.Partitions():
+			c.markAlive()
 			c.addPartition(pc)
 		case n := <-c.consumer.Notifications():
+			c.markAlive()
 			c.handleNotification(n)
 		case err := <-c.consumer.Errors():
-			c.logger.Error("consumer error", zap.String("topic", c.topic), zap.Error(err))
+			c.logger.Error("consumer error", zap.String("name", c.name), zap.Error(err))
+			if isFatalError(err) {
+				c.healthMu.Lock()
+				c.lastFatalAt = time.Now()
+				c.healthMu.Unlock()
+			}
 		case <-c.stopC:
-			c.shutdown()
-			c.logger.Info("consumer stopped", zap.String("topic", c.topic))
+			c.shutdown(c.shutdownCtx)
+			c.logger.Info("consumer stopped", zap.String("name", c.name))
 			return
 		}
 	}
 }
 
-// addPartition adds a new partition. If the partition already exist,
-// it is first stopped before overwriting it with the new partition
+// addPartition adds a new partition.
+//
+// Under the Eager protocol, every rebalance revokes and reassigns all
+// partitions, so an existing partition consumer for this key is always
+// stale and is stopped before being overwritten.
+//
+// Under the Cooperative protocol, the underlying library only emits a
+// partition here when it is genuinely new to this consumer - a
+// partition that is still owned across a rebalance is never resent -
+// so if one is already tracked it is left running untouched.
 func (c *consumerImpl) addPartition(pc cluster.PartitionConsumer) {
-	old := c.partitions.Get(pc.Partition())
+	key := partitionKey{topic: pc.Topic(), partition: pc.Partition()}
+	old := c.partitions.Get(key)
 	if old != nil {
+		if c.options.RebalanceProtocol == Cooperative {
+			return
+		}
 		old.Stop()
-		c.partitions.Delete(pc.Partition())
+		c.partitions.Delete(key)
 	}
-	c.logger.Info("new partition", zap.String("topic", c.topic), zap.Int32("id", pc.Partition()))
-	p := newPartitionConsumer(c.consumer, pc, c.options, c.msgCh, c.dlq, c.tally, c.logger)
-	c.partitions.Put(pc.Partition(), p)
+	c.logger.Info("new partition", zap.String("topic", pc.Topic()), zap.Int32("id", pc.Partition()))
+	p := newPartitionConsumer(c.consumer, pc, c.options, c.fetchCh, c.dlq, c.tally, c.logger)
+	c.partitions.Put(key, p)
 	p.Start()
 }
 
-// handleNotification is the handler that handles notifications
-// from the underlying library about partition rebalances. There
-// is no action taken in this handler except for logging.
+// handleNotification is the handler that handles notifications from the
+// underlying library about partition rebalances. Under both protocols it
+// logs the notification and records, for Ready, every topic that has a
+// claimed or current partition. Under the Eager protocol nothing else is
+// done, since addPartition already stops and recreates every owned
+// partition. Under the Cooperative
+// protocol, this is also where released partitions are drained and
+// dropped - they are not resent to addPartition, and partitions that
+// remain in Current are left running.
 func (c *consumerImpl) handleNotification(n *cluster.Notification) {
-	var ok bool
-	var claimed, released, current []int32
-	if claimed, ok = n.Claimed[c.topic]; !ok {
-		claimed = []int32{}
+	c.topicsMu.RLock()
+	topics := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		topics = append(topics, topic)
+	}
+	c.topicsMu.RUnlock()
+	for _, topic := range topics {
+		claimed := n.Claimed[topic]
+		released := n.Released[topic]
+		current := n.Current[topic]
+		c.logger.Info("cluster rebalance notification", zap.String("topic", topic),
+			zap.Int32s("claimed", claimed), zap.Int32s("released", released),
+			zap.Int32s("current", current))
+		if len(claimed) > 0 || len(current) > 0 {
+			c.healthMu.Lock()
+			c.claimed[topic] = struct{}{}
+			c.healthMu.Unlock()
+		}
+		if c.options.RebalanceProtocol == Cooperative {
+			c.releasePartitions(topic, released)
+		}
+	}
+}
+
+// markAlive records that the event loop just observed an event, used by
+// Healthy to detect a consumer that is still running but has stopped
+// making progress.
+func (c *consumerImpl) markAlive() {
+	c.healthMu.Lock()
+	c.lastEventAt = time.Now()
+	c.healthMu.Unlock()
+}
+
+// releasePartitions drains and removes the partitions released for the
+// given topic by a cooperative rebalance. Partitions not in this set
+// keep running, so their in-flight offsets are never discarded.
+// releasePartitions drains every released partition concurrently, the
+// same way shutdown drains all owned partitions, so that releasing
+// several partitions in one rebalance doesn't block the event loop -
+// and therefore the Claimed partitions from that same rebalance - for a
+// multiple of the per-partition drain deadline.
+func (c *consumerImpl) releasePartitions(topic string, released []int32) {
+	var wg sync.WaitGroup
+	for _, id := range released {
+		key := partitionKey{topic: topic, partition: id}
+		p := c.partitions.Get(key)
+		if p == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(key partitionKey, id int32, p *partitionConsumer) {
+			defer wg.Done()
+			p.Drain(2 * c.options.OffsetCommitInterval)
+			c.partitions.Delete(key)
+			c.logger.Info("released partition", zap.String("topic", topic), zap.Int32("id", id))
+		}(key, id, p)
+	}
+	wg.Wait()
+}
+
+// topicDiscoveryLoop periodically compares the topics known to the
+// cluster against this consumer's topic pattern and logs/emits a metric
+// for every newly matching topic. This only updates this package's own
+// bookkeeping (Topics(), Ready()) - bsm/sarama-cluster fixes its
+// subscribed topic list when the underlying consumer is constructed and
+// has no way to add a topic to a running group, so a newly discovered
+// topic is never actually claimed or consumed until the process is
+// restarted with it included in kafka.ConsumerConfig.Topics.
+func (c *consumerImpl) topicDiscoveryLoop() {
+	ticker := time.NewTicker(c.options.MetadataRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.discoverTopics()
+		case <-c.stopC:
+			return
+		}
+	}
+}
+
+// lagReportLoop periodically recomputes and publishes consumer lag.
+func (c *consumerImpl) lagReportLoop() {
+	ticker := time.NewTicker(c.options.LagReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.reportLag()
+		case <-c.stopC:
+			return
+		}
 	}
-	if released, ok = n.Released[c.topic]; !ok {
-		released = []int32{}
+}
+
+// reportLag computes lag for every owned partition as HighWaterMark
+// minus the partition's last committed offset, publishes a tally gauge
+// per topic/partition, and caches the result for Lag().
+func (c *consumerImpl) reportLag() {
+	hwm := c.consumer.HighWaterMarks()
+	c.topicsMu.RLock()
+	topicCount := len(c.topics)
+	c.topicsMu.RUnlock()
+	lag := make(map[string]map[int32]int64, topicCount)
+	for key, p := range c.partitions.Snapshot() {
+		topicHWM, ok := hwm[key.topic]
+		if !ok {
+			continue
+		}
+		high, ok := topicHWM[key.partition]
+		if !ok {
+			continue
+		}
+		partitionLag := high - p.CommittedOffset()
+		if partitionLag < 0 {
+			partitionLag = 0
+		}
+		if _, ok := lag[key.topic]; !ok {
+			lag[key.topic] = make(map[int32]int64)
+		}
+		lag[key.topic][key.partition] = partitionLag
+		c.tally.Tagged(map[string]string{
+			"topic":     key.topic,
+			"partition": strconv.Itoa(int(key.partition)),
+		}).Gauge(metrics.KafkaConsumerLag).Update(float64(partitionLag))
+	}
+	c.lagMu.Lock()
+	c.lag = lag
+	c.lagMu.Unlock()
+}
+
+// discoverTopics fetches the current topic list from the cluster and
+// adds any topic that matches this consumer's pattern and isn't already
+// tracked to this consumer's bookkeeping of known topics. It does not
+// cause the underlying consumer group to actually consume the topic; see
+// topicDiscoveryLoop.
+func (c *consumerImpl) discoverTopics() {
+	all, err := c.consumer.Topics()
+	if err != nil {
+		c.logger.Error("failed to refresh cluster metadata", zap.Error(err))
+		return
 	}
-	if current, ok = n.Current[c.topic]; !ok {
-		current = []int32{}
+	c.topicsMu.Lock()
+	defer c.topicsMu.Unlock()
+	for _, t := range all {
+		if _, ok := c.topics[t]; ok {
+			continue
+		}
+		if !c.topicPattern.MatchString(t) {
+			continue
+		}
+		c.topics[t] = struct{}{}
+		c.logger.Info("discovered new matching topic, not yet consumed", zap.String("topic", t), zap.String("name", c.name))
+		c.tally.Tagged(map[string]string{"topic": t}).Counter(metrics.KafkaConsumerTopicDiscovered).Inc(1)
 	}
-	c.logger.Info("cluster rebalance notification", zap.String("topic", c.topic),
-		zap.Int32s("claimed", claimed), zap.Int32s("released", released),
-		zap.Int32s("current", current))
 }
 
-// shutdown shutsdown the consumer
-func (c *consumerImpl) shutdown() {
+// shutdown shuts down the consumer. Each partition is drained up to
+// ctx's deadline, defaulting to 2*Options.OffsetCommitInterval when ctx
+// carries none, falling back to the default once ctx itself is done.
+// Whatever hasn't drained by then is abandoned and offsets are
+// force-committed for whatever has.
+func (c *consumerImpl) shutdown(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	deadline := 2 * c.options.OffsetCommitInterval
+	if dl, ok := ctx.Deadline(); ok {
+		if d := time.Until(dl); d > 0 {
+			deadline = d
+		}
+	}
+
 	var wg sync.WaitGroup
-	for _, pc := range c.partitions.partitions {
+	for _, pc := range c.partitions.Snapshot() {
 		wg.Add(1)
 		go func(p *partitionConsumer) {
-			p.Drain(2 * c.options.OffsetCommitInterval)
-			wg.Done()
+			defer wg.Done()
+			p.Drain(deadline)
 		}(pc)
 	}
-	wg.Wait()
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		c.logger.Warn("shutdown context done before all partitions drained",
+			zap.String("name", c.name), zap.Int("inflight", c.InflightCount()))
+	}
+
 	c.partitions.Clear()
 	c.consumer.CommitOffsets()
 	c.consumer.Close()
@@ -210,12 +638,14 @@ func (c *consumerImpl) shutdown() {
 // newPartitionMap returns a partitionMap, a wrapper around a map
 func newPartitionMap() partitionMap {
 	return partitionMap{
-		partitions: make(map[int32]*partitionConsumer, 8),
+		partitions: make(map[partitionKey]*partitionConsumer, 8),
 	}
 }
 
-// Get returns the partition with the given id, if it exists
-func (m *partitionMap) Get(key int32) *partitionConsumer {
+// Get returns the partition with the given key, if it exists
+func (m *partitionMap) Get(key partitionKey) *partitionConsumer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	p, ok := m.partitions[key]
 	if !ok {
 		return nil
@@ -223,14 +653,18 @@ func (m *partitionMap) Get(key int32) *partitionConsumer {
 	return p
 }
 
-// Delete deletes the partition with the given id
-func (m *partitionMap) Delete(key int32) {
+// Delete deletes the partition with the given key
+func (m *partitionMap) Delete(key partitionKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	delete(m.partitions, key)
 }
 
 // Put adds the partition with the given key
-func (m *partitionMap) Put(key int32, value *partitionConsumer) error {
-	if m.Get(key) != nil {
+func (m *partitionMap) Put(key partitionKey, value *partitionConsumer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.partitions[key]; ok {
 		return fmt.Errorf("partition already exist")
 	}
 	m.partitions[key] = value
@@ -239,7 +673,21 @@ func (m *partitionMap) Put(key int32, value *partitionConsumer) error {
 
 // Clear clears all entries in the map
 func (m *partitionMap) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for k := range m.partitions {
 		delete(m.partitions, k)
 	}
 }
+
+// Snapshot returns a point-in-time copy of the tracked partitions, safe
+// to range over concurrently with Put/Delete on the live map.
+func (m *partitionMap) Snapshot() map[partitionKey]*partitionConsumer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	snap := make(map[partitionKey]*partitionConsumer, len(m.partitions))
+	for k, v := range m.partitions {
+		snap[k] = v
+	}
+	return snap
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consumer
+
+import (
+	"sync/atomic"
+
+	"github.com/uber-go/kafka-client/kafka"
+)
+
+// trackingMessage wraps a kafka.Message delivered off fetchCh so that
+// InflightCount can tell how many delivered messages the application
+// hasn't yet Acked or Nacked.
+type trackingMessage struct {
+	kafka.Message
+	c *consumerImpl
+}
+
+// Ack acks the underlying message and clears it from the inflight count.
+func (m *trackingMessage) Ack() {
+	m.Message.Ack()
+	atomic.AddInt64(&m.c.inflight, -1)
+}
+
+// Nack nacks the underlying message and clears it from the inflight count.
+func (m *trackingMessage) Nack() {
+	m.Message.Nack()
+	atomic.AddInt64(&m.c.inflight, -1)
+}
+
+// InflightCount returns the number of messages that have been delivered
+// on Messages/MessageBatches but not yet Acked or Nacked. Operators can
+// use this to gate SIGTERM handling: wait for it to hit zero, or until a
+// StopWithContext deadline, before letting the process exit.
+func (c *consumerImpl) InflightCount() int {
+	return int(atomic.LoadInt64(&c.inflight))
+}
+
+// trackingLoop forwards messages fetched off partitions to the public
+// msgCh, wrapping each one so Ack/Nack keep the inflight count accurate.
+// It runs until the consumer is fully shut down (doneC is closed) or
+// fetchCh is closed; messages still buffered on fetchCh at shutdown are
+// not forwarded.
+func (c *consumerImpl) trackingLoop() {
+	for {
+		select {
+		case msg, ok := <-c.fetchCh:
+			if !ok {
+				return
+			}
+			atomic.AddInt64(&c.inflight, 1)
+			c.msgCh <- &trackingMessage{Message: msg, c: c}
+		case <-c.doneC:
+			return
+		}
+	}
+}
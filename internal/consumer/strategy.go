@@ -0,0 +1,40 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consumer
+
+// BalanceStrategy selects how a group's partitions are distributed across
+// its members. It is a closed set, not an extension point: the consumer
+// group itself - bsm/sarama-cluster - is the one that actually computes
+// and negotiates the assignment during JoinGroup/SyncGroup, and that
+// library only knows how to execute two strategies. A BalanceStrategy
+// value picks between them through NewClusterConfig; there is no hook
+// anywhere in this package, or in bsm/sarama-cluster, for a caller to
+// supply its own assignment algorithm, so one isn't offered here either.
+type BalanceStrategy string
+
+const (
+	// Range assigns each topic's partitions to members in contiguous
+	// ranges, ordered by member id. This is sarama's default strategy.
+	Range BalanceStrategy = "range"
+	// RoundRobin lays out every topic-partition pair in a single sorted
+	// list and hands them to members in round-robin order.
+	RoundRobin BalanceStrategy = "roundrobin"
+)